@@ -0,0 +1,217 @@
+package mapcidr
+
+import (
+	"math/big"
+	"net"
+	"sort"
+)
+
+// ipRange is an inclusive [first, last] address range, kept in integer form
+// so it can be merged and split without caring whether it came from an IPv4
+// or IPv6 CIDR.
+type ipRange struct {
+	first *big.Int
+	last  *big.Int
+	bits  int
+}
+
+func ipNetToRange(ipnet *net.IPNet) (ipRange, error) {
+	first, last, err := AddressRange(ipnet)
+	if err != nil {
+		return ipRange{}, err
+	}
+	firstInt, bits, err := IPToInteger(first)
+	if err != nil {
+		return ipRange{}, err
+	}
+	lastInt, _, err := IPToInteger(last)
+	if err != nil {
+		return ipRange{}, err
+	}
+	return ipRange{first: firstInt, last: lastInt, bits: bits}, nil
+}
+
+// mergeRanges sorts ranges and coalesces any that overlap or are adjacent.
+func mergeRanges(ranges []ipRange) []ipRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		if cmp := ranges[i].first.Cmp(ranges[j].first); cmp != 0 {
+			return cmp < 0
+		}
+		return ranges[i].last.Cmp(ranges[j].last) > 0
+	})
+
+	one := big.NewInt(1)
+	merged := []ipRange{ranges[0]}
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		// r touches or overlaps the current range if it starts at or before
+		// one past its last address.
+		if r.first.Cmp(new(big.Int).Add(last.last, one)) <= 0 {
+			if r.last.Cmp(last.last) > 0 {
+				last.last = r.last
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// rangeToCIDRs re-expresses an address range as the smallest list of CIDR
+// blocks that covers it, by repeatedly taking the largest power-of-two
+// aligned block available at the current position.
+func rangeToCIDRs(r ipRange) []*net.IPNet {
+	var cidrs []*net.IPNet
+
+	one := big.NewInt(1)
+	cur := new(big.Int).Set(r.first)
+	for cur.Cmp(r.last) <= 0 {
+		alignBits := trailingZeroBits(cur, r.bits)
+
+		span := new(big.Int).Sub(r.last, cur)
+		span.Add(span, one)
+		sizeBits := span.BitLen() - 1
+		if sizeBits < 0 {
+			sizeBits = 0
+		}
+
+		hostBits := alignBits
+		if sizeBits < hostBits {
+			hostBits = sizeBits
+		}
+
+		prefixLen := r.bits - hostBits
+		cidrs = append(cidrs, &net.IPNet{
+			IP:   IntegerToIP(cur, r.bits),
+			Mask: net.CIDRMask(prefixLen, r.bits),
+		})
+
+		blockSize := new(big.Int).Lsh(one, uint(hostBits))
+		cur = new(big.Int).Add(cur, blockSize)
+	}
+
+	return cidrs
+}
+
+// trailingZeroBits returns the number of trailing zero bits in x, treating
+// the zero address as maximally aligned.
+func trailingZeroBits(x *big.Int, bits int) int {
+	if x.Sign() == 0 {
+		return bits
+	}
+	return int(x.TrailingZeroBits())
+}
+
+func splitByFamily(ranges []ipRange) (v4, v6 []ipRange) {
+	for _, r := range ranges {
+		if r.bits == 32 {
+			v4 = append(v4, r)
+		} else {
+			v6 = append(v6, r)
+		}
+	}
+	return v4, v6
+}
+
+// Coalesce merges an arbitrary list of possibly overlapping or adjacent
+// IPv4 and IPv6 CIDRs into the minimal set of CIDRs that covers the same
+// addresses.
+func Coalesce(cidrs []*net.IPNet) []*net.IPNet {
+	ranges := make([]ipRange, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		r, err := ipNetToRange(cidr)
+		if err != nil {
+			continue
+		}
+		ranges = append(ranges, r)
+	}
+
+	v4, v6 := splitByFamily(ranges)
+
+	var result []*net.IPNet
+	for _, r := range mergeRanges(v4) {
+		result = append(result, rangeToCIDRs(r)...)
+	}
+	for _, r := range mergeRanges(v6) {
+		result = append(result, rangeToCIDRs(r)...)
+	}
+	return result
+}
+
+// CoalesceCIDRs is the string based wrapper around Coalesce.
+func CoalesceCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	ipnets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		ipnets = append(ipnets, ipnet)
+	}
+	return Coalesce(ipnets), nil
+}
+
+// RemoveCIDRs subtracts the deny set of CIDRs from the allow set and returns
+// the smallest set of CIDRs covering the difference.
+func RemoveCIDRs(allow, deny []*net.IPNet) []*net.IPNet {
+	allowRanges := make([]ipRange, 0, len(allow))
+	for _, cidr := range allow {
+		r, err := ipNetToRange(cidr)
+		if err != nil {
+			continue
+		}
+		allowRanges = append(allowRanges, r)
+	}
+	denyRanges := make([]ipRange, 0, len(deny))
+	for _, cidr := range deny {
+		r, err := ipNetToRange(cidr)
+		if err != nil {
+			continue
+		}
+		denyRanges = append(denyRanges, r)
+	}
+
+	allowV4, allowV6 := splitByFamily(allowRanges)
+	denyV4, denyV6 := splitByFamily(denyRanges)
+
+	var result []*net.IPNet
+	result = append(result, subtractRanges(mergeRanges(allowV4), mergeRanges(denyV4))...)
+	result = append(result, subtractRanges(mergeRanges(allowV6), mergeRanges(denyV6))...)
+	return result
+}
+
+// subtractRanges removes every deny range from every allow range, splitting
+// each allow range around the overlapping portion of each deny range it
+// intersects.
+func subtractRanges(allow, deny []ipRange) []*net.IPNet {
+	one := big.NewInt(1)
+
+	var result []*net.IPNet
+	for _, a := range allow {
+		remaining := []ipRange{a}
+		for _, d := range deny {
+			var next []ipRange
+			for _, r := range remaining {
+				if d.last.Cmp(r.first) < 0 || d.first.Cmp(r.last) > 0 {
+					next = append(next, r)
+					continue
+				}
+				if d.first.Cmp(r.first) > 0 {
+					next = append(next, ipRange{first: r.first, last: new(big.Int).Sub(d.first, one), bits: r.bits})
+				}
+				if d.last.Cmp(r.last) < 0 {
+					next = append(next, ipRange{first: new(big.Int).Add(d.last, one), last: r.last, bits: r.bits})
+				}
+			}
+			remaining = next
+		}
+		for _, r := range remaining {
+			result = append(result, rangeToCIDRs(r)...)
+		}
+	}
+	return result
+}
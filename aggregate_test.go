@@ -0,0 +1,49 @@
+package mapcidr
+
+import (
+	"net"
+	"testing"
+)
+
+func mustParseCIDRs(t *testing.T, cidrs ...string) []*net.IPNet {
+	t.Helper()
+	ipnets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ipnets = append(ipnets, ipnet)
+	}
+	return ipnets
+}
+
+func cidrStrings(ipnets []*net.IPNet) []string {
+	strs := make([]string, len(ipnets))
+	for i, ipnet := range ipnets {
+		strs[i] = ipnet.String()
+	}
+	return strs
+}
+
+func TestCoalesceAdjacent(t *testing.T) {
+	cidrs := mustParseCIDRs(t, "10.0.0.0/25", "10.0.0.128/25")
+	got := cidrStrings(Coalesce(cidrs))
+
+	want := []string{"10.0.0.0/24"}
+	if len(got) != 1 || got[0] != want[0] {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestRemoveCIDRs(t *testing.T) {
+	allow := mustParseCIDRs(t, "10.0.0.0/24")
+	deny := mustParseCIDRs(t, "10.0.0.128/25")
+
+	got := cidrStrings(RemoveCIDRs(allow, deny))
+
+	want := []string{"10.0.0.0/25"}
+	if len(got) != 1 || got[0] != want[0] {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
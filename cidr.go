@@ -2,7 +2,6 @@
 package mapcidr
 
 import (
-	"encoding/binary"
 	"fmt"
 	"math"
 	"math/big"
@@ -42,10 +41,36 @@ func AddressCount(cidr string) (uint64, error) {
 	return AddressCountIpnet(ipnet), nil
 }
 
-// AddressCountIpnet returns the number of IP addresses in an IPNet structure
+// AddressCountIpnet returns the number of IP addresses in an IPNet structure.
+// If the true count does not fit in a uint64 (an IPv6 prefix of /64 or
+// shorter), it returns math.MaxUint64; use AddressCountBig or
+// AddressCountChecked to detect and handle that case.
 func AddressCountIpnet(network *net.IPNet) uint64 {
+	count, ok := AddressCountChecked(network)
+	if !ok {
+		return math.MaxUint64
+	}
+	return count
+}
+
+// AddressCountChecked returns the number of IP addresses in network and
+// whether that count fits in a uint64. ok is false when the exact count
+// overflows uint64, in which case AddressCountBig should be used instead.
+func AddressCountChecked(network *net.IPNet) (count uint64, ok bool) {
+	countBig := AddressCountBig(network)
+	if !countBig.IsUint64() {
+		return math.MaxUint64, false
+	}
+	return countBig.Uint64(), true
+}
+
+// AddressCountBig returns the exact number of IP addresses in network as a
+// big.Int. Unlike AddressCountIpnet it never overflows, so it is the
+// canonical way to size large IPv6 ranges.
+func AddressCountBig(network *net.IPNet) *big.Int {
 	prefixLen, bits := network.Mask.Size()
-	return 1 << (uint64(bits) - uint64(prefixLen))
+	count := big.NewInt(1)
+	return count.Lsh(count, uint(bits-prefixLen))
 }
 
 // SplitByNumber splits the given cidr into subnets with the closest
@@ -77,6 +102,48 @@ func SplitN(iprange string, n int) ([]*net.IPNet, error) {
 	return SplitIPNetIntoN(ipnet, n)
 }
 
+// SplitByMask splits the given cidr into every subnet of newPrefix length
+// contained within it.
+func SplitByMask(cidr string, newPrefix int) ([]*net.IPNet, error) {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	return SplitIPNetByMask(ipnet, newPrefix)
+}
+
+// SplitIPNetByMask splits ipnet into every subnet of newPrefix length
+// contained within it, e.g. splitting a /16 into a newPrefix of 24 returns
+// every /24 inside it.
+func SplitIPNetByMask(ipnet *net.IPNet, newPrefix int) ([]*net.IPNet, error) {
+	maskBits, bits := ipnet.Mask.Size()
+	if newPrefix < maskBits {
+		return nil, fmt.Errorf("new prefix /%d must be no smaller than the parent prefix /%d", newPrefix, maskBits)
+	}
+	if newPrefix > bits {
+		return nil, fmt.Errorf("new prefix /%d exceeds address length of %d bits", newPrefix, bits)
+	}
+
+	current, err := currentSubnet(ipnet, newPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	subnets := []*net.IPNet{current}
+	for {
+		next, wrapped, err := nextSubnet(current, newPrefix)
+		if err != nil {
+			return nil, err
+		}
+		if wrapped || !ipnet.Contains(next.IP) {
+			break
+		}
+		subnets = append(subnets, next)
+		current = next
+	}
+	return subnets, nil
+}
+
 // SplitIPNetIntoN attempts to split a ipnet in the exact number of subnets
 func SplitIPNetIntoN(iprange *net.IPNet, n int) ([]*net.IPNet, error) {
 	var err error
@@ -216,6 +283,30 @@ func closestPowerOfTwo(v uint32) uint32 {
 	return next
 }
 
+// inc increments an IP address in place, treating it as a big-endian
+// unsigned integer, and returns it.
+func inc(ip net.IP) net.IP {
+	for i := len(ip) - 1; i >= 0; i-- {
+		ip[i]++
+		if ip[i] != 0 {
+			break
+		}
+	}
+	return ip
+}
+
+// dec decrements an IP address in place, treating it as a big-endian
+// unsigned integer, and returns it.
+func dec(ip net.IP) net.IP {
+	for i := len(ip) - 1; i >= 0; i-- {
+		ip[i]--
+		if ip[i] != 0xff {
+			break
+		}
+	}
+	return ip
+}
+
 func currentSubnet(network *net.IPNet, prefixLen int) (*net.IPNet, error) {
 	currentFirst, _, err := AddressRange(network)
 	if err != nil {
@@ -283,20 +374,13 @@ func IPAddresses(cidr string) ([]string, error) {
 	return IPAddressesIPnet(ipnet), nil
 }
 
-// IPAddressesIPnet returns all IP addresses in an IPNet.
+// IPAddressesIPnet returns all IP addresses in an IPNet. It supports both
+// IPv4 and IPv6 ranges; for ranges too large to hold in memory (a /8 or
+// bigger, or most IPv6 prefixes) prefer IPAddressesIter or
+// IPAddressesAsStream, which never materialise the full address list.
 func IPAddressesIPnet(ipnet *net.IPNet) (ips []string) {
-	// convert IPNet struct mask and address to uint32
-	mask := binary.BigEndian.Uint32(ipnet.Mask)
-	start := binary.BigEndian.Uint32(ipnet.IP)
-
-	// find the final address
-	finish := (start & mask) | (mask ^ 0xffffffff)
-
-	// loop through addresses as uint32
-	for i := start; i <= finish; i++ {
-		// convert back to net.IP
-		ip := make(net.IP, 4)
-		binary.BigEndian.PutUint32(ip, i)
+	next := IPAddressesIter(ipnet)
+	for ip, ok := next(); ok; ip, ok = next() {
 		ips = append(ips, ip.String())
 	}
 	return ips
@@ -0,0 +1,101 @@
+package mapcidr
+
+import (
+	"math"
+	"math/big"
+	"net"
+	"testing"
+)
+
+func TestPreviousSubnet(t *testing.T) {
+	_, network, err := net.ParseCIDR("10.0.1.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous, wrapped := PreviousSubnet(network, 24)
+	if wrapped {
+		t.Fatalf("did not expect wraparound for %s", network)
+	}
+	if previous.String() != "10.0.0.0/24" {
+		t.Fatalf("expected 10.0.0.0/24, got %s", previous)
+	}
+}
+
+func TestNextSubnet(t *testing.T) {
+	_, network, err := net.ParseCIDR("10.0.0.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next, wrapped, err := nextSubnet(network, 24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wrapped {
+		t.Fatalf("did not expect wraparound for %s", network)
+	}
+	if next.String() != "10.0.1.0/24" {
+		t.Fatalf("expected 10.0.1.0/24, got %s", next)
+	}
+}
+
+func TestSplitIPNetByMask(t *testing.T) {
+	_, parent, err := net.ParseCIDR("10.0.0.0/16")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subnets, err := SplitIPNetByMask(parent, 24)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(subnets) != 256 {
+		t.Fatalf("expected 256 /24 subnets, got %d", len(subnets))
+	}
+	if subnets[0].String() != "10.0.0.0/24" {
+		t.Fatalf("expected first subnet 10.0.0.0/24, got %s", subnets[0])
+	}
+	if last := subnets[len(subnets)-1]; last.String() != "10.0.255.0/24" {
+		t.Fatalf("expected last subnet 10.0.255.0/24, got %s", last)
+	}
+}
+
+func TestAddressCountIpnetOverflow(t *testing.T) {
+	_, network, err := net.ParseCIDR("2001:db8::/32")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := AddressCountIpnet(network); got != math.MaxUint64 {
+		t.Fatalf("expected math.MaxUint64 for an overflowing count, got %d", got)
+	}
+
+	want := new(big.Int).Lsh(big.NewInt(1), 96)
+	if got := AddressCountBig(network); got.Cmp(want) != 0 {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+
+	if _, ok := AddressCountChecked(network); ok {
+		t.Fatal("expected ok=false for a count that overflows uint64")
+	}
+}
+
+func TestIPAddressesIPnetIPv6(t *testing.T) {
+	_, network, err := net.ParseCIDR("2001:db8::/126")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ips := IPAddressesIPnet(network)
+	want := []string{"2001:db8::", "2001:db8::1", "2001:db8::2", "2001:db8::3"}
+	if len(ips) != len(want) {
+		t.Fatalf("expected %d addresses, got %d: %v", len(want), len(ips), ips)
+	}
+	for i := range want {
+		if ips[i] != want[i] {
+			t.Fatalf("at index %d: expected %s, got %s", i, want[i], ips[i])
+		}
+	}
+}
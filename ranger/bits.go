@@ -0,0 +1,39 @@
+package ranger
+
+import (
+	"fmt"
+	"net"
+)
+
+// addrBits returns the address length, in bits, of an IP address: 32 for
+// IPv4 and 128 for IPv6.
+func addrBits(ip net.IP) (int, error) {
+	if ip.To4() != nil {
+		return 32, nil
+	}
+	if len(ip) == net.IPv6len {
+		return 128, nil
+	}
+	return 0, fmt.Errorf("unsupported ip address length %d", len(ip))
+}
+
+// bitAt returns the bit at position pos (0 being the most significant bit)
+// of ip, normalising 4-in-16 byte IPv4 addresses down to 4 bytes first.
+func bitAt(ip net.IP, pos int) int {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	byteIdx := pos / 8
+	bitIdx := 7 - uint(pos%8)
+	return int((ip[byteIdx] >> bitIdx) & 1)
+}
+
+// cloneIP returns a copy of ip, normalised to its shortest representation.
+func cloneIP(ip net.IP) net.IP {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	dup := make(net.IP, len(ip))
+	copy(dup, ip)
+	return dup
+}
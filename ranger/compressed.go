@@ -0,0 +1,251 @@
+package ranger
+
+import (
+	"fmt"
+	"net"
+)
+
+// CompressedRanger is a path-compressed variant of Ranger: runs of trie
+// nodes with a single child are collapsed into one node carrying the full
+// bit prefix they represent, keeping memory proportional to the number of
+// inserted networks rather than to their bit length. This matters most for
+// IPv6, where an uncompressed trie can have well over a hundred levels per
+// entry. The zero value is not usable; construct one with NewCompressed.
+type CompressedRanger struct {
+	v4 *cnode
+	v6 *cnode
+}
+
+// NewCompressed returns an empty CompressedRanger ready for use.
+func NewCompressed() *CompressedRanger {
+	return &CompressedRanger{}
+}
+
+// cnode stores the absolute bit prefix (from the root) that it represents,
+// rather than just the single bit an uncompressed node implicitly encodes.
+type cnode struct {
+	ip        net.IP
+	prefixLen int
+	children  [2]*cnode
+	network   *net.IPNet
+	value     interface{}
+	hasEntry  bool
+}
+
+func (r *CompressedRanger) rootPtr(bits int) (**cnode, error) {
+	switch bits {
+	case 32:
+		return &r.v4, nil
+	case 128:
+		return &r.v6, nil
+	default:
+		return nil, fmt.Errorf("unsupported prefix bit length %d", bits)
+	}
+}
+
+// Insert adds network to the trie, associating it with value. Inserting a
+// network that is already present overwrites its value.
+func (r *CompressedRanger) Insert(network *net.IPNet, value interface{}) error {
+	bits, err := addrBits(network.IP)
+	if err != nil {
+		return err
+	}
+	root, err := r.rootPtr(bits)
+	if err != nil {
+		return err
+	}
+
+	ones, _ := network.Mask.Size()
+	insertCompressed(root, network.IP, ones, network, value)
+	return nil
+}
+
+func insertCompressed(nodePtr **cnode, ip net.IP, prefixLen int, network *net.IPNet, value interface{}) {
+	n := *nodePtr
+	if n == nil {
+		*nodePtr = &cnode{ip: cloneIP(ip), prefixLen: prefixLen, network: network, value: value, hasEntry: true}
+		return
+	}
+
+	common := commonPrefixLen(n.ip, ip, n.prefixLen, prefixLen)
+
+	switch {
+	case common == n.prefixLen && common == prefixLen:
+		// Exact match: overwrite.
+		n.network = network
+		n.value = value
+		n.hasEntry = true
+
+	case common == n.prefixLen:
+		// n is a strict ancestor of the new entry; descend past it.
+		bit := bitAt(ip, n.prefixLen)
+		insertCompressed(&n.children[bit], ip, prefixLen, network, value)
+
+	case common == prefixLen:
+		// The new entry is a strict ancestor of n; splice it in above n.
+		bit := bitAt(n.ip, prefixLen)
+		replacement := &cnode{ip: cloneIP(ip), prefixLen: prefixLen, network: network, value: value, hasEntry: true}
+		replacement.children[bit] = n
+		*nodePtr = replacement
+
+	default:
+		// Neither is an ancestor of the other; split the edge at their
+		// common prefix and hang both off the new branch node.
+		branch := &cnode{ip: cloneIP(ip), prefixLen: common}
+		branch.children[bitAt(n.ip, common)] = n
+		branch.children[bitAt(ip, common)] = &cnode{ip: cloneIP(ip), prefixLen: prefixLen, network: network, value: value, hasEntry: true}
+		*nodePtr = branch
+	}
+}
+
+// commonPrefixLen returns the number of leading bits a and b share, up to
+// min(maxA, maxB).
+func commonPrefixLen(a, b net.IP, maxA, maxB int) int {
+	limit := maxA
+	if maxB < limit {
+		limit = maxB
+	}
+	for i := 0; i < limit; i++ {
+		if bitAt(a, i) != bitAt(b, i) {
+			return i
+		}
+	}
+	return limit
+}
+
+// prefixMatches reports whether ip agrees with n's stored prefix over all
+// of n's prefixLen bits.
+func prefixMatches(n *cnode, ip net.IP) bool {
+	return commonPrefixLen(n.ip, ip, n.prefixLen, n.prefixLen) == n.prefixLen
+}
+
+// Remove clears the entry at network, if present. It does not merge the
+// surrounding nodes back together, trading a little extra memory for a
+// much simpler implementation.
+func (r *CompressedRanger) Remove(network *net.IPNet) error {
+	bits, err := addrBits(network.IP)
+	if err != nil {
+		return err
+	}
+	root, err := r.rootPtr(bits)
+	if err != nil {
+		return err
+	}
+
+	ones, _ := network.Mask.Size()
+	cur := *root
+	for cur != nil {
+		if cur.prefixLen == ones && prefixMatches(cur, network.IP) {
+			cur.network = nil
+			cur.value = nil
+			cur.hasEntry = false
+			return nil
+		}
+		if cur.prefixLen >= ones || !prefixMatches(cur, network.IP) {
+			return nil
+		}
+		cur = cur.children[bitAt(network.IP, cur.prefixLen)]
+	}
+	return nil
+}
+
+// Contains reports whether ip falls within the most specific network
+// previously inserted that covers it, and returns the value associated
+// with that network.
+func (r *CompressedRanger) Contains(ip net.IP) (bool, interface{}, error) {
+	bits, err := addrBits(ip)
+	if err != nil {
+		return false, nil, err
+	}
+	root, err := r.rootPtr(bits)
+	if err != nil {
+		return false, nil, err
+	}
+
+	found := false
+	var value interface{}
+	for cur := *root; cur != nil; {
+		if !prefixMatches(cur, ip) {
+			break
+		}
+		if cur.hasEntry {
+			found = true
+			value = cur.value
+		}
+		if cur.prefixLen >= bits {
+			break
+		}
+		cur = cur.children[bitAt(ip, cur.prefixLen)]
+	}
+	return found, value, nil
+}
+
+// ContainingNetworks returns every network previously inserted that
+// contains ip, ordered from least to most specific.
+func (r *CompressedRanger) ContainingNetworks(ip net.IP) ([]*net.IPNet, error) {
+	bits, err := addrBits(ip)
+	if err != nil {
+		return nil, err
+	}
+	root, err := r.rootPtr(bits)
+	if err != nil {
+		return nil, err
+	}
+
+	var networks []*net.IPNet
+	for cur := *root; cur != nil; {
+		if !prefixMatches(cur, ip) {
+			break
+		}
+		if cur.hasEntry {
+			networks = append(networks, cur.network)
+		}
+		if cur.prefixLen >= bits {
+			break
+		}
+		cur = cur.children[bitAt(ip, cur.prefixLen)]
+	}
+	return networks, nil
+}
+
+// CoveredNetworks returns every network previously inserted whose prefix is
+// contained within network.
+func (r *CompressedRanger) CoveredNetworks(network *net.IPNet) ([]*net.IPNet, error) {
+	bits, err := addrBits(network.IP)
+	if err != nil {
+		return nil, err
+	}
+	root, err := r.rootPtr(bits)
+	if err != nil {
+		return nil, err
+	}
+
+	ones, _ := network.Mask.Size()
+	cur := *root
+	for cur != nil {
+		if cur.prefixLen >= ones {
+			if commonPrefixLen(cur.ip, network.IP, ones, ones) == ones {
+				var networks []*net.IPNet
+				collectCompressed(cur, &networks)
+				return networks, nil
+			}
+			return nil, nil
+		}
+		if !prefixMatches(cur, network.IP) {
+			return nil, nil
+		}
+		cur = cur.children[bitAt(network.IP, cur.prefixLen)]
+	}
+	return nil, nil
+}
+
+func collectCompressed(n *cnode, networks *[]*net.IPNet) {
+	if n == nil {
+		return
+	}
+	if n.hasEntry {
+		*networks = append(*networks, n.network)
+	}
+	collectCompressed(n.children[0], networks)
+	collectCompressed(n.children[1], networks)
+}
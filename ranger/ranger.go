@@ -0,0 +1,202 @@
+// Package ranger indexes CIDR networks into a binary trie keyed on their
+// network-number bits, for fast containment and longest-prefix-match
+// lookups. It is intended for use as an in-memory ACL, geo-IP, or
+// routing-table backend.
+package ranger
+
+import (
+	"fmt"
+	"net"
+)
+
+// Ranger indexes a set of IPv4 and IPv6 networks into a binary trie. The
+// zero value is not usable; construct one with New.
+type Ranger struct {
+	v4 *node
+	v6 *node
+}
+
+// New returns an empty Ranger ready for use.
+func New() *Ranger {
+	return &Ranger{
+		v4: &node{},
+		v6: &node{},
+	}
+}
+
+// node is a single bit position in the trie. A node with hasEntry set
+// stores the network and value that were inserted at that exact prefix.
+type node struct {
+	children [2]*node
+	network  *net.IPNet
+	value    interface{}
+	hasEntry bool
+}
+
+func (r *Ranger) rootFor(bits int) (*node, error) {
+	switch bits {
+	case 32:
+		return r.v4, nil
+	case 128:
+		return r.v6, nil
+	default:
+		return nil, fmt.Errorf("unsupported prefix bit length %d", bits)
+	}
+}
+
+// Insert adds network to the trie, associating it with value. Inserting a
+// network that is already present overwrites its value.
+func (r *Ranger) Insert(network *net.IPNet, value interface{}) error {
+	bits, err := addrBits(network.IP)
+	if err != nil {
+		return err
+	}
+	root, err := r.rootFor(bits)
+	if err != nil {
+		return err
+	}
+
+	ones, _ := network.Mask.Size()
+	cur := root
+	for i := 0; i < ones; i++ {
+		bit := bitAt(network.IP, i)
+		if cur.children[bit] == nil {
+			cur.children[bit] = &node{}
+		}
+		cur = cur.children[bit]
+	}
+
+	cur.network = network
+	cur.value = value
+	cur.hasEntry = true
+	return nil
+}
+
+// Remove deletes network from the trie, if present.
+func (r *Ranger) Remove(network *net.IPNet) error {
+	bits, err := addrBits(network.IP)
+	if err != nil {
+		return err
+	}
+	root, err := r.rootFor(bits)
+	if err != nil {
+		return err
+	}
+
+	ones, _ := network.Mask.Size()
+	cur := root
+	for i := 0; i < ones; i++ {
+		bit := bitAt(network.IP, i)
+		if cur.children[bit] == nil {
+			return nil
+		}
+		cur = cur.children[bit]
+	}
+
+	cur.network = nil
+	cur.value = nil
+	cur.hasEntry = false
+	return nil
+}
+
+// Contains reports whether ip falls within the most specific network
+// previously inserted that covers it, and returns the value associated
+// with that network.
+func (r *Ranger) Contains(ip net.IP) (bool, interface{}, error) {
+	bits, err := addrBits(ip)
+	if err != nil {
+		return false, nil, err
+	}
+	root, err := r.rootFor(bits)
+	if err != nil {
+		return false, nil, err
+	}
+
+	found := false
+	var value interface{}
+	cur := root
+	for i := 0; i <= bits; i++ {
+		if cur.hasEntry {
+			found = true
+			value = cur.value
+		}
+		if i == bits {
+			break
+		}
+		bit := bitAt(ip, i)
+		if cur.children[bit] == nil {
+			break
+		}
+		cur = cur.children[bit]
+	}
+	return found, value, nil
+}
+
+// ContainingNetworks returns every network previously inserted that
+// contains ip, ordered from least to most specific.
+func (r *Ranger) ContainingNetworks(ip net.IP) ([]*net.IPNet, error) {
+	bits, err := addrBits(ip)
+	if err != nil {
+		return nil, err
+	}
+	root, err := r.rootFor(bits)
+	if err != nil {
+		return nil, err
+	}
+
+	var networks []*net.IPNet
+	cur := root
+	for i := 0; i <= bits; i++ {
+		if cur.hasEntry {
+			networks = append(networks, cur.network)
+		}
+		if i == bits {
+			break
+		}
+		bit := bitAt(ip, i)
+		if cur.children[bit] == nil {
+			break
+		}
+		cur = cur.children[bit]
+	}
+	return networks, nil
+}
+
+// CoveredNetworks returns every network previously inserted whose prefix is
+// contained within network, i.e. every entry that network is equal to or
+// less specific than.
+func (r *Ranger) CoveredNetworks(network *net.IPNet) ([]*net.IPNet, error) {
+	bits, err := addrBits(network.IP)
+	if err != nil {
+		return nil, err
+	}
+	root, err := r.rootFor(bits)
+	if err != nil {
+		return nil, err
+	}
+
+	ones, _ := network.Mask.Size()
+	cur := root
+	for i := 0; i < ones; i++ {
+		bit := bitAt(network.IP, i)
+		if cur.children[bit] == nil {
+			return nil, nil
+		}
+		cur = cur.children[bit]
+	}
+
+	var networks []*net.IPNet
+	collect(cur, &networks)
+	return networks, nil
+}
+
+func collect(n *node, networks *[]*net.IPNet) {
+	if n == nil {
+		return
+	}
+	if n.hasEntry {
+		*networks = append(*networks, n.network)
+	}
+	collect(n.children[0], networks)
+	collect(n.children[1], networks)
+}
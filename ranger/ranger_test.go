@@ -0,0 +1,98 @@
+package ranger
+
+import (
+	"net"
+	"testing"
+)
+
+func mustParseCIDR(t *testing.T, cidr string) *net.IPNet {
+	t.Helper()
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ipnet
+}
+
+func TestRangerContains(t *testing.T) {
+	r := New()
+	if err := r.Insert(mustParseCIDR(t, "10.0.0.0/8"), "outer"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Insert(mustParseCIDR(t, "10.1.0.0/16"), "inner"); err != nil {
+		t.Fatal(err)
+	}
+
+	found, value, err := r.Contains(net.ParseIP("10.1.2.3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found || value != "inner" {
+		t.Fatalf("expected the most specific match inner, got found=%v value=%v", found, value)
+	}
+
+	found, _, err = r.Contains(net.ParseIP("192.168.0.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Fatal("did not expect a match for an unrelated address")
+	}
+}
+
+func TestRangerContainingNetworks(t *testing.T) {
+	r := New()
+	outer := mustParseCIDR(t, "10.0.0.0/8")
+	inner := mustParseCIDR(t, "10.1.0.0/16")
+	if err := r.Insert(outer, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Insert(inner, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	networks, err := r.ContainingNetworks(net.ParseIP("10.1.2.3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(networks) != 2 || networks[0].String() != outer.String() || networks[1].String() != inner.String() {
+		t.Fatalf("expected [%s %s], got %v", outer, inner, networks)
+	}
+}
+
+func TestRangerCoveredNetworks(t *testing.T) {
+	r := New()
+	if err := r.Insert(mustParseCIDR(t, "10.1.0.0/16"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Insert(mustParseCIDR(t, "10.2.0.0/16"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	networks, err := r.CoveredNetworks(mustParseCIDR(t, "10.0.0.0/8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(networks) != 2 {
+		t.Fatalf("expected 2 covered networks, got %d: %v", len(networks), networks)
+	}
+}
+
+func TestRangerRemove(t *testing.T) {
+	r := New()
+	network := mustParseCIDR(t, "10.0.0.0/8")
+	if err := r.Insert(network, "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Remove(network); err != nil {
+		t.Fatal(err)
+	}
+
+	found, _, err := r.Contains(net.ParseIP("10.1.2.3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Fatal("did not expect a match after removal")
+	}
+}
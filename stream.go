@@ -0,0 +1,84 @@
+package mapcidr
+
+import (
+	"context"
+	"math/big"
+	"net"
+)
+
+// strideOf returns the stride to advance by between successive addresses.
+// No value, or a value of zero, means every address is returned.
+func strideOf(stride []uint64) uint64 {
+	if len(stride) == 0 || stride[0] == 0 {
+		return 1
+	}
+	return stride[0]
+}
+
+// IPAddressesIter returns a pull iterator over every address in ipnet.
+// Each call returns the next address and true, or a zero value and false
+// once the range is exhausted. Unlike IPAddressesIPnet it never allocates
+// the full address list, and it supports IPv6 as well as IPv4 by walking
+// addresses as big.Int rather than assuming a 4-byte representation.
+//
+// An optional stride skips (stride-1) addresses between each one returned,
+// which is useful for sampling a large range instead of visiting every
+// address in it.
+func IPAddressesIter(ipnet *net.IPNet, stride ...uint64) func() (net.IP, bool) {
+	first, last, err := AddressRange(ipnet)
+	if err != nil {
+		return func() (net.IP, bool) { return nil, false }
+	}
+
+	firstInt, bits, err := IPToInteger(first)
+	if err != nil {
+		return func() (net.IP, bool) { return nil, false }
+	}
+	lastInt, _, err := IPToInteger(last)
+	if err != nil {
+		return func() (net.IP, bool) { return nil, false }
+	}
+
+	step := new(big.Int).SetUint64(strideOf(stride))
+	current := firstInt
+
+	return func() (net.IP, bool) {
+		if current.Cmp(lastInt) > 0 {
+			return nil, false
+		}
+		ip := IntegerToIP(current, bits)
+		current = new(big.Int).Add(current, step)
+		return ip, true
+	}
+}
+
+// IPAddressesAsStream returns a channel that yields every address in ipnet
+// in order, without materialising them into a slice first. It accepts the
+// same optional stride as IPAddressesIter, and is the channel-based
+// counterpart for callers that want to feed a large range (a /8, or an
+// IPv6 subnet) into a worker pool without exhausting memory.
+//
+// Canceling ctx stops the producer goroutine and closes the channel even if
+// the consumer has stopped reading, which avoids leaking the goroutine when
+// a caller abandons the stream early.
+func IPAddressesAsStream(ctx context.Context, ipnet *net.IPNet, stride ...uint64) <-chan net.IP {
+	ch := make(chan net.IP)
+
+	go func() {
+		defer close(ch)
+		next := IPAddressesIter(ipnet, stride...)
+		for {
+			ip, ok := next()
+			if !ok {
+				return
+			}
+			select {
+			case ch <- ip:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch
+}
@@ -0,0 +1,58 @@
+package mapcidr
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestIPAddressesAsStream(t *testing.T) {
+	_, ipnet, err := net.ParseCIDR("10.0.0.0/30")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	ch := IPAddressesAsStream(ctx, ipnet)
+
+	var got []string
+	for ip := range ch {
+		got = append(got, ip.String())
+	}
+
+	want := []string{"10.0.0.0", "10.0.0.1", "10.0.0.2", "10.0.0.3"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d addresses, got %d: %v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("at index %d: expected %s, got %s", i, want[i], got[i])
+		}
+	}
+}
+
+func TestIPAddressesAsStreamCancel(t *testing.T) {
+	_, ipnet, err := net.ParseCIDR("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := IPAddressesAsStream(ctx, ipnet)
+	<-ch
+
+	done := make(chan struct{})
+	go func() {
+		for range ch {
+		}
+		close(done)
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("producer goroutine did not stop after context cancellation")
+	}
+}
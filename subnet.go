@@ -0,0 +1,147 @@
+package mapcidr
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+)
+
+// Subnet returns the num'th subnet of base after extending its prefix by
+// newBits.
+//
+// For example, Subnet for 10.3.0.0/16, a newBits of 8 and a num of 2 returns
+// 10.3.2.0/24.
+func Subnet(base *net.IPNet, newBits int, num int) (*net.IPNet, error) {
+	parentLen, addrLen := base.Mask.Size()
+	if newBits < 0 || newBits > addrLen-parentLen {
+		return nil, fmt.Errorf("insufficient address space to extend prefix of %d by %d", parentLen, newBits)
+	}
+	newPrefixLen := parentLen + newBits
+
+	maxSubnets := new(big.Int).Lsh(big.NewInt(1), uint(newBits))
+	if num < 0 || big.NewInt(int64(num)).Cmp(maxSubnets) >= 0 {
+		return nil, fmt.Errorf("prefix extension of %d does not accommodate a subnet numbered %d", newBits, num)
+	}
+
+	baseInt, bits, err := IPToInteger(base.IP)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := new(big.Int).Lsh(big.NewInt(int64(num)), uint(addrLen-newPrefixLen))
+	subnetInt := new(big.Int).Or(baseInt, offset)
+
+	return &net.IPNet{
+		IP:   IntegerToIP(subnetInt, bits),
+		Mask: net.CIDRMask(newPrefixLen, addrLen),
+	}, nil
+}
+
+// Host returns the num'th host address within base, where 0 is the network
+// address itself. A negative num counts backwards from the last address in
+// base, with -1 being the last address (the broadcast address for IPv4).
+func Host(base *net.IPNet, num int) (net.IP, error) {
+	ones, bits := base.Mask.Size()
+	hostLen := uint(bits - ones)
+
+	max := new(big.Int).Lsh(big.NewInt(1), hostLen)
+
+	numBig := big.NewInt(int64(num))
+	if num < 0 {
+		numBig.Add(max, numBig)
+		if numBig.Sign() < 0 {
+			return nil, fmt.Errorf("host number %d does not exist in a network of size %s", num, max)
+		}
+	}
+	if numBig.Cmp(max) >= 0 {
+		return nil, fmt.Errorf("host number %d does not exist in a network of size %s", num, max)
+	}
+
+	baseInt, _, err := IPToInteger(base.IP)
+	if err != nil {
+		return nil, err
+	}
+
+	hostInt := new(big.Int).Or(baseInt, numBig)
+	return IntegerToIP(hostInt, bits), nil
+}
+
+// PreviousSubnet returns the subnet of length prefixLen that contains the
+// address immediately before network's first address; network's own mask is
+// not used. It is the exported counterpart of previousSubnet. The returned
+// bool reports whether network starts at the zero address, in which case the
+// result wraps around to the last subnet of the address space.
+func PreviousSubnet(network *net.IPNet, prefixLen int) (*net.IPNet, bool) {
+	return previousSubnet(network, prefixLen)
+}
+
+// VerifyNoOverlap checks that subnets are fully contained within parent,
+// that none of them overlap one another, and that together they tile
+// parent without gaps.
+func VerifyNoOverlap(subnets []*net.IPNet, parent *net.IPNet) error {
+	if len(subnets) == 0 {
+		return fmt.Errorf("no subnets provided to cover %s", parent)
+	}
+
+	parentFirst, parentLast, err := AddressRange(parent)
+	if err != nil {
+		return err
+	}
+	parentFirstInt, _, err := IPToInteger(parentFirst)
+	if err != nil {
+		return err
+	}
+	parentLastInt, _, err := IPToInteger(parentLast)
+	if err != nil {
+		return err
+	}
+
+	type bounded struct {
+		network *net.IPNet
+		first   *big.Int
+		last    *big.Int
+	}
+
+	ranges := make([]bounded, 0, len(subnets))
+	for _, subnet := range subnets {
+		first, last, err := AddressRange(subnet)
+		if err != nil {
+			return err
+		}
+		firstInt, _, err := IPToInteger(first)
+		if err != nil {
+			return err
+		}
+		lastInt, _, err := IPToInteger(last)
+		if err != nil {
+			return err
+		}
+		if firstInt.Cmp(parentFirstInt) < 0 || lastInt.Cmp(parentLastInt) > 0 {
+			return fmt.Errorf("%s is not contained within %s", subnet, parent)
+		}
+		ranges = append(ranges, bounded{network: subnet, first: firstInt, last: lastInt})
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].first.Cmp(ranges[j].first) < 0
+	})
+
+	if ranges[0].first.Cmp(parentFirstInt) != 0 {
+		return fmt.Errorf("%s does not cover the start of %s, leaving a gap", ranges[0].network, parent)
+	}
+	if last := ranges[len(ranges)-1]; last.last.Cmp(parentLastInt) != 0 {
+		return fmt.Errorf("%s does not cover the end of %s, leaving a gap", last.network, parent)
+	}
+
+	for i := 1; i < len(ranges); i++ {
+		switch gap := ranges[i].first.Cmp(new(big.Int).Add(ranges[i-1].last, big.NewInt(1))); {
+		case gap < 0:
+			return fmt.Errorf("%s overlaps with %s", ranges[i].network, ranges[i-1].network)
+		case gap > 0:
+			return fmt.Errorf("gap between %s and %s", ranges[i-1].network, ranges[i].network)
+		}
+	}
+
+	return nil
+}
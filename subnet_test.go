@@ -0,0 +1,85 @@
+package mapcidr
+
+import (
+	"math"
+	"net"
+	"testing"
+)
+
+func TestSubnetLargeNewBits(t *testing.T) {
+	_, base, err := net.ParseCIDR("2001:db8::/64")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subnet, err := Subnet(base, 64, 0)
+	if err != nil {
+		t.Fatalf("unexpected error for newBits=64, num=0: %v", err)
+	}
+	if subnet.String() != "2001:db8::/128" {
+		t.Fatalf("expected 2001:db8::/128, got %s", subnet)
+	}
+}
+
+func TestSubnetRejectsNegativeNewBits(t *testing.T) {
+	_, base, err := net.ParseCIDR("10.0.0.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Subnet(base, -1, 0); err == nil {
+		t.Fatal("expected an error for a negative newBits")
+	}
+}
+
+func TestSubnetRejectsHugeNewBits(t *testing.T) {
+	_, base, err := net.ParseCIDR("10.0.0.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Subnet(base, math.MaxInt-10, 0); err == nil {
+		t.Fatal("expected an error for a newBits that overflows the address length")
+	}
+}
+
+func TestVerifyNoOverlapEmptySubnets(t *testing.T) {
+	_, parent, err := net.ParseCIDR("10.0.0.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyNoOverlap(nil, parent); err == nil {
+		t.Fatal("expected an error for an empty subnets slice")
+	}
+}
+
+func TestVerifyNoOverlapGap(t *testing.T) {
+	_, parent, err := net.ParseCIDR("10.0.0.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, half, err := net.ParseCIDR("10.0.0.0/25")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyNoOverlap([]*net.IPNet{half}, parent); err == nil {
+		t.Fatal("expected an error for a subnet that leaves half of the parent uncovered")
+	}
+}
+
+func TestVerifyNoOverlapFullTiling(t *testing.T) {
+	_, parent, err := net.ParseCIDR("10.0.0.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	subnets, err := SplitIPNetByMask(parent, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyNoOverlap(subnets, parent); err != nil {
+		t.Fatalf("expected no error for a full tiling, got %v", err)
+	}
+}